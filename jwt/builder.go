@@ -0,0 +1,295 @@
+/*-
+ * Copyright 2016 Zbigniew Mandziejewicz
+ * Copyright 2016 Square, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jwt
+
+import (
+	"reflect"
+	"strings"
+
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/json"
+)
+
+// Builder is a utility for constructing signed, encrypted, or
+// signed-then-encrypted JSON Web Tokens. Calls can be chained; any error is
+// deferred until Token, CompactSerialize or FullSerialize is called.
+type Builder interface {
+	// Claims merges i into the token's claims. i may be a struct (matched
+	// via its json tags) or a map[string]interface{}. Multiple calls merge
+	// into a single JSON object; a call whose claim value has a different
+	// type than one already set makes Token, CompactSerialize and
+	// FullSerialize return ErrInvalidClaims.
+	Claims(i interface{}) Builder
+	// Token builds a JSONWebToken from the accumulated claims.
+	Token() (*JSONWebToken, error)
+	// CompactSerialize serializes the token using compact serialization.
+	CompactSerialize() (string, error)
+	// FullSerialize serializes the token using full serialization.
+	FullSerialize() (string, error)
+}
+
+type builder struct {
+	claims map[string]interface{}
+	err    error
+}
+
+type signedBuilder struct {
+	builder
+	sig jose.Signer
+}
+
+type encryptedBuilder struct {
+	builder
+	enc jose.Encrypter
+}
+
+type signedAndEncryptedBuilder struct {
+	builder
+	sig jose.Signer
+	enc jose.Encrypter
+}
+
+// Signed constructs a Builder that produces a signed JWT (JWS) using sig.
+func Signed(sig jose.Signer) Builder {
+	return &signedBuilder{sig: sig}
+}
+
+// Encrypted constructs a Builder that produces an encrypted JWT (JWE) using
+// enc.
+func Encrypted(enc jose.Encrypter) Builder {
+	return &encryptedBuilder{enc: enc}
+}
+
+// SignedAndEncrypted constructs a Builder that signs the claims with sig
+// and then encrypts the resulting JWS with enc, producing a nested token in
+// the form ParseSignedAndEncrypted expects. enc must have been constructed
+// with its content type set to "JWT" (e.g. via
+// (&jose.EncrypterOptions{}).WithContentType("JWT")); otherwise Token,
+// CompactSerialize and FullSerialize return ErrInvalidContentType.
+func SignedAndEncrypted(sig jose.Signer, enc jose.Encrypter) Builder {
+	return &signedAndEncryptedBuilder{sig: sig, enc: enc}
+}
+
+func (b builder) with(i interface{}) builder {
+	if b.err != nil {
+		return b
+	}
+
+	m, err := toClaimsMap(i)
+	if err != nil {
+		b.err = err
+		return b
+	}
+
+	merged := make(map[string]interface{}, len(b.claims)+len(m))
+	for k, v := range b.claims {
+		merged[k] = v
+	}
+	for k, v := range m {
+		if existing, ok := merged[k]; ok && reflect.TypeOf(existing) != reflect.TypeOf(v) {
+			b.err = ErrInvalidClaims
+			return b
+		}
+		merged[k] = v
+	}
+
+	b.claims = merged
+	return b
+}
+
+func toClaimsMap(i interface{}) (map[string]interface{}, error) {
+	if m, ok := i.(map[string]interface{}); ok {
+		return m, nil
+	}
+
+	raw, err := json.Marshal(i)
+	if err != nil {
+		return nil, ErrInvalidClaims
+	}
+
+	m := make(map[string]interface{})
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, ErrInvalidClaims
+	}
+	return m, nil
+}
+
+func (b builder) payload() ([]byte, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return json.Marshal(b.claims)
+}
+
+// jwsToken wraps a freshly-built jws into a JSONWebToken whose Claims still
+// requires the matching verification key to succeed, exactly like a
+// JSONWebToken obtained from ParseSigned.
+func jwsToken(jws *jose.JSONWebSignature) *JSONWebToken {
+	headers := make([]jose.Header, len(jws.Signatures))
+	for i, sig := range jws.Signatures {
+		headers[i] = sig.Header
+	}
+
+	return &JSONWebToken{
+		payload:           jws.Verify,
+		unverifiedPayload: jws.UnsafePayloadWithoutVerification,
+		Headers:           headers,
+	}
+}
+
+// jweToken wraps a freshly-built jwe into a JSONWebToken whose Claims still
+// requires the matching decryption key to succeed, exactly like a
+// JSONWebToken obtained from ParseEncrypted.
+func jweToken(jwe *jose.JSONWebEncryption) *JSONWebToken {
+	return &JSONWebToken{
+		payload: jwe.Decrypt,
+		Headers: []jose.Header{jwe.Header},
+	}
+}
+
+func (b *signedBuilder) Claims(i interface{}) Builder {
+	return &signedBuilder{builder: b.builder.with(i), sig: b.sig}
+}
+
+func (b *signedBuilder) sign() (*jose.JSONWebSignature, error) {
+	p, err := b.payload()
+	if err != nil {
+		return nil, err
+	}
+	return b.sig.Sign(p)
+}
+
+func (b *signedBuilder) Token() (*JSONWebToken, error) {
+	jws, err := b.sign()
+	if err != nil {
+		return nil, err
+	}
+	return jwsToken(jws), nil
+}
+
+func (b *signedBuilder) CompactSerialize() (string, error) {
+	jws, err := b.sign()
+	if err != nil {
+		return "", err
+	}
+	return jws.CompactSerialize()
+}
+
+func (b *signedBuilder) FullSerialize() (string, error) {
+	jws, err := b.sign()
+	if err != nil {
+		return "", err
+	}
+	return jws.FullSerialize(), nil
+}
+
+func (b *encryptedBuilder) Claims(i interface{}) Builder {
+	return &encryptedBuilder{builder: b.builder.with(i), enc: b.enc}
+}
+
+func (b *encryptedBuilder) encrypt() (*jose.JSONWebEncryption, error) {
+	p, err := b.payload()
+	if err != nil {
+		return nil, err
+	}
+	return b.enc.Encrypt(p)
+}
+
+func (b *encryptedBuilder) Token() (*JSONWebToken, error) {
+	jwe, err := b.encrypt()
+	if err != nil {
+		return nil, err
+	}
+	return jweToken(jwe), nil
+}
+
+func (b *encryptedBuilder) CompactSerialize() (string, error) {
+	jwe, err := b.encrypt()
+	if err != nil {
+		return "", err
+	}
+	return jwe.CompactSerialize()
+}
+
+func (b *encryptedBuilder) FullSerialize() (string, error) {
+	jwe, err := b.encrypt()
+	if err != nil {
+		return "", err
+	}
+	return jwe.FullSerialize(), nil
+}
+
+func (b *signedAndEncryptedBuilder) Claims(i interface{}) Builder {
+	return &signedAndEncryptedBuilder{builder: b.builder.with(i), sig: b.sig, enc: b.enc}
+}
+
+// nest signs the claims with b.sig and encrypts the result with b.enc,
+// returning both the inner jws (needed by Token, which exposes the nested
+// token pre-decryption, the same way NestedJSONWebToken.Decrypt does) and
+// the outer jwe (needed for serialization).
+func (b *signedAndEncryptedBuilder) nest() (*jose.JSONWebSignature, *jose.JSONWebEncryption, error) {
+	if strings.ToUpper(string(b.enc.Options().ContentType)) != "JWT" {
+		return nil, nil, ErrInvalidContentType
+	}
+
+	p, err := b.payload()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	jws, err := b.sig.Sign(p)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	compact, err := jws.CompactSerialize()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	jwe, err := b.enc.Encrypt([]byte(compact))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return jws, jwe, nil
+}
+
+func (b *signedAndEncryptedBuilder) Token() (*JSONWebToken, error) {
+	jws, _, err := b.nest()
+	if err != nil {
+		return nil, err
+	}
+	return jwsToken(jws), nil
+}
+
+func (b *signedAndEncryptedBuilder) CompactSerialize() (string, error) {
+	_, jwe, err := b.nest()
+	if err != nil {
+		return "", err
+	}
+	return jwe.CompactSerialize()
+}
+
+func (b *signedAndEncryptedBuilder) FullSerialize() (string, error) {
+	_, jwe, err := b.nest()
+	if err != nil {
+		return "", err
+	}
+	return jwe.FullSerialize(), nil
+}
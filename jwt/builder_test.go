@@ -0,0 +1,234 @@
+/*-
+ * Copyright 2016 Zbigniew Mandziejewicz
+ * Copyright 2016 Square, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jwt
+
+import (
+	"testing"
+
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestBuilderSignedRoundTrip(t *testing.T) {
+	key := mustRSAKey(t)
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key}, nil)
+	if err != nil {
+		t.Fatalf("new signer: %v", err)
+	}
+
+	raw, err := Signed(signer).Claims(testClaims{Subject: "alice"}).CompactSerialize()
+	if err != nil {
+		t.Fatalf("build token: %v", err)
+	}
+
+	tok, err := ParseSigned(raw)
+	if err != nil {
+		t.Fatalf("parse signed: %v", err)
+	}
+
+	var out testClaims
+	if err := tok.Claims(key.Public(), &out); err != nil {
+		t.Fatalf("verify claims: %v", err)
+	}
+	if out.Subject != "alice" {
+		t.Fatalf("got subject %q, want %q", out.Subject, "alice")
+	}
+}
+
+func TestBuilderEncryptedRoundTrip(t *testing.T) {
+	key := mustRSAKey(t)
+	encrypter, err := jose.NewEncrypter(jose.A128GCM, jose.Recipient{Algorithm: jose.RSA_OAEP, Key: key.Public()}, nil)
+	if err != nil {
+		t.Fatalf("new encrypter: %v", err)
+	}
+
+	raw, err := Encrypted(encrypter).Claims(testClaims{Subject: "alice"}).CompactSerialize()
+	if err != nil {
+		t.Fatalf("build token: %v", err)
+	}
+
+	tok, err := ParseEncrypted(raw)
+	if err != nil {
+		t.Fatalf("parse encrypted: %v", err)
+	}
+
+	var out testClaims
+	if err := tok.Claims(key, &out); err != nil {
+		t.Fatalf("decrypt claims: %v", err)
+	}
+	if out.Subject != "alice" {
+		t.Fatalf("got subject %q, want %q", out.Subject, "alice")
+	}
+}
+
+func TestBuilderSignedAndEncryptedRoundTrip(t *testing.T) {
+	key := mustRSAKey(t)
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key}, nil)
+	if err != nil {
+		t.Fatalf("new signer: %v", err)
+	}
+
+	encrypter, err := jose.NewEncrypter(
+		jose.A128GCM,
+		jose.Recipient{Algorithm: jose.RSA_OAEP, Key: key.Public()},
+		(&jose.EncrypterOptions{}).WithContentType("JWT"),
+	)
+	if err != nil {
+		t.Fatalf("new encrypter: %v", err)
+	}
+
+	raw, err := SignedAndEncrypted(signer, encrypter).Claims(testClaims{Subject: "alice"}).CompactSerialize()
+	if err != nil {
+		t.Fatalf("build token: %v", err)
+	}
+
+	nested, err := ParseSignedAndEncrypted(raw)
+	if err != nil {
+		t.Fatalf("parse signed and encrypted: %v", err)
+	}
+
+	var out testClaims
+	if err := nested.Decrypt(key).Claims(key.Public(), &out); err != nil {
+		t.Fatalf("decrypt and verify claims: %v", err)
+	}
+	if out.Subject != "alice" {
+		t.Fatalf("got subject %q, want %q", out.Subject, "alice")
+	}
+}
+
+func TestBuilderSignedTokenRequiresCorrectKey(t *testing.T) {
+	key := mustRSAKey(t)
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key}, nil)
+	if err != nil {
+		t.Fatalf("new signer: %v", err)
+	}
+
+	tok, err := Signed(signer).Claims(testClaims{Subject: "alice"}).Token()
+	if err != nil {
+		t.Fatalf("build token: %v", err)
+	}
+
+	other := mustRSAKey(t)
+	var out testClaims
+	if err := tok.Claims(other.Public(), &out); err == nil {
+		t.Fatalf("Claims succeeded with the wrong key")
+	}
+	if err := tok.Claims(nil, &out); err == nil {
+		t.Fatalf("Claims succeeded with a nil key")
+	}
+
+	out = testClaims{}
+	if err := tok.Claims(key.Public(), &out); err != nil {
+		t.Fatalf("verify claims with the correct key: %v", err)
+	}
+	if out.Subject != "alice" {
+		t.Fatalf("got subject %q, want %q", out.Subject, "alice")
+	}
+}
+
+func TestBuilderEncryptedTokenRequiresCorrectKey(t *testing.T) {
+	key := mustRSAKey(t)
+	encrypter, err := jose.NewEncrypter(jose.A128GCM, jose.Recipient{Algorithm: jose.RSA_OAEP, Key: key.Public()}, nil)
+	if err != nil {
+		t.Fatalf("new encrypter: %v", err)
+	}
+
+	tok, err := Encrypted(encrypter).Claims(testClaims{Subject: "alice"}).Token()
+	if err != nil {
+		t.Fatalf("build token: %v", err)
+	}
+
+	other := mustRSAKey(t)
+	var out testClaims
+	if err := tok.Claims(other, &out); err == nil {
+		t.Fatalf("Claims succeeded with the wrong key")
+	}
+	if err := tok.Claims(nil, &out); err == nil {
+		t.Fatalf("Claims succeeded with a nil key")
+	}
+
+	out = testClaims{}
+	if err := tok.Claims(key, &out); err != nil {
+		t.Fatalf("decrypt claims with the correct key: %v", err)
+	}
+	if out.Subject != "alice" {
+		t.Fatalf("got subject %q, want %q", out.Subject, "alice")
+	}
+}
+
+func TestBuilderSignedAndEncryptedTokenRequiresCorrectKey(t *testing.T) {
+	key := mustRSAKey(t)
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key}, nil)
+	if err != nil {
+		t.Fatalf("new signer: %v", err)
+	}
+
+	encrypter, err := jose.NewEncrypter(
+		jose.A128GCM,
+		jose.Recipient{Algorithm: jose.RSA_OAEP, Key: key.Public()},
+		(&jose.EncrypterOptions{}).WithContentType("JWT"),
+	)
+	if err != nil {
+		t.Fatalf("new encrypter: %v", err)
+	}
+
+	// Token exposes the nested JWS pre-decryption, the same way
+	// NestedJSONWebToken.Decrypt does, so it must be verified with the
+	// signing key, not the encryption key.
+	tok, err := SignedAndEncrypted(signer, encrypter).Claims(testClaims{Subject: "alice"}).Token()
+	if err != nil {
+		t.Fatalf("build token: %v", err)
+	}
+
+	other := mustRSAKey(t)
+	var out testClaims
+	if err := tok.Claims(other.Public(), &out); err == nil {
+		t.Fatalf("Claims succeeded with the wrong key")
+	}
+	if err := tok.Claims(nil, &out); err == nil {
+		t.Fatalf("Claims succeeded with a nil key")
+	}
+
+	out = testClaims{}
+	if err := tok.Claims(key.Public(), &out); err != nil {
+		t.Fatalf("verify claims with the correct key: %v", err)
+	}
+	if out.Subject != "alice" {
+		t.Fatalf("got subject %q, want %q", out.Subject, "alice")
+	}
+}
+
+func TestBuilderSignedAndEncryptedRejectsWrongContentType(t *testing.T) {
+	key := mustRSAKey(t)
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key}, nil)
+	if err != nil {
+		t.Fatalf("new signer: %v", err)
+	}
+
+	encrypter, err := jose.NewEncrypter(jose.A128GCM, jose.Recipient{Algorithm: jose.RSA_OAEP, Key: key.Public()}, nil)
+	if err != nil {
+		t.Fatalf("new encrypter: %v", err)
+	}
+
+	_, err = SignedAndEncrypted(signer, encrypter).Claims(testClaims{Subject: "alice"}).CompactSerialize()
+	if err != ErrInvalidContentType {
+		t.Fatalf("got error %v, want %v", err, ErrInvalidContentType)
+	}
+}
@@ -0,0 +1,125 @@
+/*-
+ * Copyright 2016 Zbigniew Mandziejewicz
+ * Copyright 2016 Square, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jwt
+
+import (
+	"strconv"
+	"time"
+
+	"gopkg.in/square/go-jose.v2/json"
+)
+
+// Claims represents the registered claims from RFC 7519, section 4.1.
+type Claims struct {
+	Issuer    string       `json:"iss,omitempty"`
+	Subject   string       `json:"sub,omitempty"`
+	Audience  Audience     `json:"aud,omitempty"`
+	Expiry    *NumericDate `json:"exp,omitempty"`
+	NotBefore *NumericDate `json:"nbf,omitempty"`
+	IssuedAt  *NumericDate `json:"iat,omitempty"`
+	ID        string       `json:"jti,omitempty"`
+}
+
+// NumericDate represents date and time as the number of seconds since the
+// Unix epoch, as specified in RFC 7519, section 2.
+type NumericDate int64
+
+// NewNumericDate constructs a NumericDate from a time.Time value, returning
+// nil for the zero time so the field is omitted from the serialized claims.
+func NewNumericDate(t time.Time) *NumericDate {
+	if t.IsZero() {
+		return nil
+	}
+	d := NumericDate(t.Unix())
+	return &d
+}
+
+// Time returns the time.Time representation of n, or the zero time if n is
+// nil.
+func (n *NumericDate) Time() time.Time {
+	if n == nil {
+		return time.Time{}
+	}
+	return time.Unix(int64(*n), 0)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (n NumericDate) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatInt(int64(n), 10)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (n *NumericDate) UnmarshalJSON(b []byte) error {
+	f, err := strconv.ParseFloat(string(b), 64)
+	if err != nil {
+		return ErrInvalidClaims
+	}
+	*n = NumericDate(f)
+	return nil
+}
+
+// Audience represents the recipients that a token is intended for, encoded
+// as either a single string or an array of strings (RFC 7519, section 4.1.3).
+type Audience []string
+
+// MarshalJSON implements json.Marshaler, encoding a single-element Audience
+// as a bare string to match the common case in the wild.
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting either a single
+// string or an array of strings.
+func (a *Audience) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case string:
+		*a = Audience{v}
+	case []interface{}:
+		parsed := make(Audience, len(v))
+		for i, e := range v {
+			s, ok := e.(string)
+			if !ok {
+				return ErrInvalidClaims
+			}
+			parsed[i] = s
+		}
+		*a = parsed
+	default:
+		return ErrInvalidClaims
+	}
+
+	return nil
+}
+
+// Contains reports whether v is one of the values in a.
+func (a Audience) Contains(v string) bool {
+	for _, e := range a {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
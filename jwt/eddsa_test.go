@@ -0,0 +1,139 @@
+/*-
+ * Copyright 2016 Zbigniew Mandziejewicz
+ * Copyright 2016 Square, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"gopkg.in/square/go-jose.v2"
+)
+
+func mustEd25519Key(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ed25519 key: %v", err)
+	}
+	return pub, priv
+}
+
+// mustECDHKey returns an EC P-256 key pair for use with ECDH-ES encryption.
+// The vendored gopkg.in/square/go-jose.v2 used by this package implements
+// ECDH-ES only over NIST curves, not X25519, so EC is the closest available
+// stand-in for the X25519/ECDH-ES pairing called for alongside EdDSA
+// signing.
+func mustECDHKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ecdsa key: %v", err)
+	}
+	return key
+}
+
+func TestEdDSASignedRoundTrip(t *testing.T) {
+	pub, priv := mustEd25519Key(t)
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.EdDSA, Key: priv}, nil)
+	if err != nil {
+		t.Fatalf("new signer: %v", err)
+	}
+
+	raw, err := Signed(signer).Claims(testClaims{Subject: "alice"}).CompactSerialize()
+	if err != nil {
+		t.Fatalf("build token: %v", err)
+	}
+
+	tok, err := ParseSigned(raw)
+	if err != nil {
+		t.Fatalf("parse signed: %v", err)
+	}
+
+	var out testClaims
+	if err := tok.Claims(pub, &out); err != nil {
+		t.Fatalf("verify claims: %v", err)
+	}
+	if out.Subject != "alice" {
+		t.Fatalf("got subject %q, want %q", out.Subject, "alice")
+	}
+}
+
+func TestECDHEncryptedRoundTrip(t *testing.T) {
+	key := mustECDHKey(t)
+	encrypter, err := jose.NewEncrypter(jose.A128GCM, jose.Recipient{Algorithm: jose.ECDH_ES, Key: &key.PublicKey}, nil)
+	if err != nil {
+		t.Fatalf("new encrypter: %v", err)
+	}
+
+	raw, err := Encrypted(encrypter).Claims(testClaims{Subject: "alice"}).CompactSerialize()
+	if err != nil {
+		t.Fatalf("build token: %v", err)
+	}
+
+	tok, err := ParseEncrypted(raw)
+	if err != nil {
+		t.Fatalf("parse encrypted: %v", err)
+	}
+
+	var out testClaims
+	if err := tok.Claims(key, &out); err != nil {
+		t.Fatalf("decrypt claims: %v", err)
+	}
+	if out.Subject != "alice" {
+		t.Fatalf("got subject %q, want %q", out.Subject, "alice")
+	}
+}
+
+func TestEdDSASignedAndECDHEncryptedRoundTrip(t *testing.T) {
+	sigPub, sigPriv := mustEd25519Key(t)
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.EdDSA, Key: sigPriv}, nil)
+	if err != nil {
+		t.Fatalf("new signer: %v", err)
+	}
+
+	encKey := mustECDHKey(t)
+	encrypter, err := jose.NewEncrypter(
+		jose.A128GCM,
+		jose.Recipient{Algorithm: jose.ECDH_ES, Key: &encKey.PublicKey},
+		(&jose.EncrypterOptions{}).WithContentType("JWT"),
+	)
+	if err != nil {
+		t.Fatalf("new encrypter: %v", err)
+	}
+
+	raw, err := SignedAndEncrypted(signer, encrypter).Claims(testClaims{Subject: "alice"}).CompactSerialize()
+	if err != nil {
+		t.Fatalf("build token: %v", err)
+	}
+
+	nested, err := ParseSignedAndEncrypted(raw)
+	if err != nil {
+		t.Fatalf("parse signed and encrypted: %v", err)
+	}
+
+	var out testClaims
+	if err := nested.Decrypt(encKey).Claims(sigPub, &out); err != nil {
+		t.Fatalf("decrypt and verify claims: %v", err)
+	}
+	if out.Subject != "alice" {
+		t.Fatalf("got subject %q, want %q", out.Subject, "alice")
+	}
+}
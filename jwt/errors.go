@@ -0,0 +1,70 @@
+/*-
+ * Copyright 2016 Zbigniew Mandziejewicz
+ * Copyright 2016 Square, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jwt
+
+import "errors"
+
+// Error indicating invalid content.
+var (
+	// ErrInvalidContentType is returned by ParseSignedAndEncrypted when the
+	// outer JWE's content type doesn't indicate it carries a JWT.
+	ErrInvalidContentType = errors.New("square/go-jose/jwt: expected content type to be JWT (cty header)")
+
+	// ErrDisallowedAlgorithm is returned when a JWS declares a signature
+	// algorithm that isn't in the caller's AllowedSignatureAlgorithms list.
+	ErrDisallowedAlgorithm = errors.New("square/go-jose/jwt: unexpected signature algorithm")
+
+	// ErrNoMatchingKey is returned by JSONWebToken.ClaimsFromKeySet (and
+	// NestedJSONWebToken.DecryptWithKeySet) when no key in the key set is
+	// usable for the token's "kid"/"alg". When usable keys were tried and
+	// none verified the token, the last verification error is returned
+	// instead.
+	ErrNoMatchingKey = errors.New("square/go-jose/jwt: no matching signing key found in key set")
+
+	// ErrInvalidClaims indicates that claims could not be parsed or built: a
+	// NumericDate or Audience claim (exp, nbf, iat or aud) holds a value of
+	// the wrong type, a Builder was given claims that do not marshal to a
+	// JSON object or whose type conflicts with a claim already set, or
+	// UnsafeClaimsWithoutVerification was called on a token that has no
+	// unverified payload.
+	ErrInvalidClaims = errors.New("square/go-jose/jwt: invalid claims")
+
+	// ErrInvalidIssuer indicates that the "iss" claim did not match
+	// Expected.Issuer.
+	ErrInvalidIssuer = errors.New("square/go-jose/jwt: validation failed, invalid issuer claim (iss)")
+
+	// ErrInvalidSubject indicates that the "sub" claim did not match
+	// Expected.Subject.
+	ErrInvalidSubject = errors.New("square/go-jose/jwt: validation failed, invalid subject claim (sub)")
+
+	// ErrInvalidAudience indicates that the "aud" claim did not contain any
+	// of Expected.AnyAudience.
+	ErrInvalidAudience = errors.New("square/go-jose/jwt: validation failed, invalid audience claim (aud)")
+
+	// ErrInvalidID indicates that the "jti" claim did not match
+	// Expected.ID.
+	ErrInvalidID = errors.New("square/go-jose/jwt: validation failed, invalid ID claim (jti)")
+
+	// ErrNotValidYet indicates that the current time is before the "nbf"
+	// claim, even after leeway is applied.
+	ErrNotValidYet = errors.New("square/go-jose/jwt: validation failed, token not valid yet (nbf)")
+
+	// ErrExpired indicates that the current time is after the "exp" claim,
+	// even after leeway is applied.
+	ErrExpired = errors.New("square/go-jose/jwt: validation failed, token is expired (exp)")
+)
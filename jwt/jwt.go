@@ -25,14 +25,58 @@ import (
 
 // JSONWebToken represents a JSON Web Token (as specified in RFC7519).
 type JSONWebToken struct {
-	err     error
-	payload func(k interface{}) ([]byte, error)
-	Headers []jose.Header
+	err               error
+	payload           func(k interface{}) ([]byte, error)
+	unverifiedPayload func() []byte
+	Headers           []jose.Header
 }
 
 type NestedJSONWebToken struct {
 	enc     *jose.JSONWebEncryption
 	Headers []jose.Header
+
+	allowedSignatureAlgorithms []jose.SignatureAlgorithm
+}
+
+// ParseSignedOpts holds options for ParseSignedWithOpts.
+type ParseSignedOpts struct {
+	// AllowedSignatureAlgorithms lists the JWS "alg" values that will be
+	// accepted. A token whose signature(s) declare any other algorithm is
+	// rejected with ErrDisallowedAlgorithm before Verify is attempted. If
+	// left nil, DefaultAllowedSignatureAlgorithms is used instead.
+	AllowedSignatureAlgorithms []jose.SignatureAlgorithm
+}
+
+// ParseSignedAndEncryptedOpts holds options for
+// ParseSignedAndEncryptedWithOpts.
+type ParseSignedAndEncryptedOpts struct {
+	// AllowedSignatureAlgorithms is applied to the nested JWS once it has
+	// been decrypted, via NestedJSONWebToken.Decrypt. See
+	// ParseSignedOpts.AllowedSignatureAlgorithms.
+	AllowedSignatureAlgorithms []jose.SignatureAlgorithm
+}
+
+// DefaultAllowedSignatureAlgorithms is the allow-list used by ParseSigned,
+// ParseSignedAndEncrypted and their *WithOpts variants when the caller
+// doesn't supply an explicit AllowedSignatureAlgorithms. Applications that
+// only ever verify with asymmetric keys can mutate this package-level
+// default (or pass their own list via ParseSignedOpts) to reject HS256,
+// HS384 and HS512 globally.
+var DefaultAllowedSignatureAlgorithms = []jose.SignatureAlgorithm{
+	jose.HS256, jose.HS384, jose.HS512,
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.ES256, jose.ES384, jose.ES512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.EdDSA,
+}
+
+func isAlgorithmAllowed(alg jose.SignatureAlgorithm, allowed []jose.SignatureAlgorithm) bool {
+	for _, a := range allowed {
+		if a == alg {
+			return true
+		}
+	}
+	return false
 }
 
 // Claims deserializes a JSONWebToken into dest using the provided key.
@@ -55,13 +99,92 @@ func (t *JSONWebToken) Claims(key interface{}, dest ...interface{}) error {
 	return nil
 }
 
+// UnsafeClaimsWithoutVerification deserializes the claims into dest without
+// verifying the token's signature. This is only safe to use when the
+// authenticity of the token has already been established by other means,
+// e.g. to read the claims of the JWS returned by NestedJSONWebToken.Decrypt
+// before its signature has been checked. Tokens from ParseEncrypted or an
+// Encrypted Builder carry no unverified payload; for them it returns
+// ErrInvalidClaims. Most callers want Claims or ClaimsFromKeySet instead.
+func (t *JSONWebToken) UnsafeClaimsWithoutVerification(dest ...interface{}) error {
+	if t.unverifiedPayload == nil {
+		return ErrInvalidClaims
+	}
+
+	b := t.unverifiedPayload()
+	for _, d := range dest {
+		if err := json.Unmarshal(b, d); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ClaimsFromKeySet deserializes claims into dest, verifying the token
+// against a key selected from ks. For each signature header, it looks up
+// candidate keys by "kid" (falling back to every key in ks when the header
+// carries none, or none of them matches), keeps only those whose Algorithm
+// and Use are compatible with the header's "alg", and returns the result of
+// the first one that verifies successfully.
+func (t *JSONWebToken) ClaimsFromKeySet(ks *jose.JSONWebKeySet, dest ...interface{}) error {
+	if t.err != nil {
+		return t.err
+	}
+
+	var lastErr error
+	for _, header := range t.Headers {
+		candidates := ks.Key(header.KeyID)
+		if len(candidates) == 0 {
+			candidates = ks.Keys
+		}
+
+		for _, key := range candidates {
+			if !keyUsableForHeader(key, header) {
+				continue
+			}
+
+			b, err := t.payload(key.Key)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+
+			for _, d := range dest {
+				if err := json.Unmarshal(b, d); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	if lastErr != nil {
+		return lastErr
+	}
+	return ErrNoMatchingKey
+}
+
+// keyUsableForHeader reports whether key may be used to verify a signature
+// with the given header, based on the key's declared Algorithm and Use.
+// Keys that don't declare either are assumed usable.
+func keyUsableForHeader(key jose.JSONWebKey, header jose.Header) bool {
+	if key.Use != "" && key.Use != "sig" {
+		return false
+	}
+	if key.Algorithm != "" && key.Algorithm != header.Algorithm {
+		return false
+	}
+	return true
+}
+
 func (t *NestedJSONWebToken) Decrypt(decryptionKey interface{}) *JSONWebToken {
 	b, err := t.enc.Decrypt(decryptionKey)
 	if err != nil {
 		return &JSONWebToken{err: err}
 	}
 
-	sig, err := ParseSigned(string(b))
+	sig, err := ParseSignedWithOpts(string(b), ParseSignedOpts{AllowedSignatureAlgorithms: t.allowedSignatureAlgorithms})
 	if err != nil {
 		return &JSONWebToken{err: err}
 	}
@@ -69,21 +192,45 @@ func (t *NestedJSONWebToken) Decrypt(decryptionKey interface{}) *JSONWebToken {
 	return sig
 }
 
+// DecryptWithKeySet decrypts t with decryptionKey and verifies the
+// resulting nested JWS against a key selected from ks, as described by
+// JSONWebToken.ClaimsFromKeySet, deserializing the claims into dest.
+func (t *NestedJSONWebToken) DecryptWithKeySet(decryptionKey interface{}, ks *jose.JSONWebKeySet, dest ...interface{}) error {
+	return t.Decrypt(decryptionKey).ClaimsFromKeySet(ks, dest...)
+}
+
 // ParseSigned parses token from JWS form.
 func ParseSigned(s string) (*JSONWebToken, error) {
+	return ParseSignedWithOpts(s, ParseSignedOpts{})
+}
+
+// ParseSignedWithOpts parses token from JWS form, rejecting any signature
+// whose "alg" header isn't in opts.AllowedSignatureAlgorithms before
+// attempting verification.
+func ParseSignedWithOpts(s string, opts ParseSignedOpts) (*JSONWebToken, error) {
 	sig, err := jose.ParseSigned(s)
 	if err != nil {
 		return nil, err
 	}
+
+	allowed := opts.AllowedSignatureAlgorithms
+	if allowed == nil {
+		allowed = DefaultAllowedSignatureAlgorithms
+	}
+
 	headers := make([]jose.Header, len(sig.Signatures))
 	for i, signature := range sig.Signatures {
+		if !isAlgorithmAllowed(jose.SignatureAlgorithm(signature.Header.Algorithm), allowed) {
+			return nil, ErrDisallowedAlgorithm
+		}
 		headers[i] = signature.Header
 	}
 
 	return &JSONWebToken{
-		err:     nil,
-		payload: sig.Verify,
-		Headers: headers,
+		err:               nil,
+		payload:           sig.Verify,
+		unverifiedPayload: sig.UnsafePayloadWithoutVerification,
+		Headers:           headers,
 	}, nil
 }
 
@@ -103,6 +250,13 @@ func ParseEncrypted(s string) (*JSONWebToken, error) {
 
 // ParseSignedAndEncrypted parses signed-then-encrypted token from JWE form.
 func ParseSignedAndEncrypted(s string) (*NestedJSONWebToken, error) {
+	return ParseSignedAndEncryptedWithOpts(s, ParseSignedAndEncryptedOpts{})
+}
+
+// ParseSignedAndEncryptedWithOpts parses signed-then-encrypted token from
+// JWE form, applying opts.AllowedSignatureAlgorithms to the nested JWS once
+// it is decrypted via NestedJSONWebToken.Decrypt.
+func ParseSignedAndEncryptedWithOpts(s string, opts ParseSignedAndEncryptedOpts) (*NestedJSONWebToken, error) {
 	enc, err := jose.ParseEncrypted(s)
 	if err != nil {
 		return nil, err
@@ -113,7 +267,8 @@ func ParseSignedAndEncrypted(s string) (*NestedJSONWebToken, error) {
 	}
 
 	return &NestedJSONWebToken{
-		enc:     enc,
-		Headers: []jose.Header{enc.Header},
+		enc:                        enc,
+		Headers:                    []jose.Header{enc.Header},
+		allowedSignatureAlgorithms: opts.AllowedSignatureAlgorithms,
 	}, nil
 }
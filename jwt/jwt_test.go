@@ -0,0 +1,112 @@
+/*-
+ * Copyright 2016 Zbigniew Mandziejewicz
+ * Copyright 2016 Square, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"gopkg.in/square/go-jose.v2"
+)
+
+// testClaims is a minimal claims struct shared by tests in this package.
+type testClaims struct {
+	Subject string `json:"sub"`
+}
+
+func mustRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+	return key
+}
+
+func TestClaimsFromKeySet(t *testing.T) {
+	key := mustRSAKey(t)
+	pub := key.Public()
+
+	cases := []struct {
+		name    string
+		kid     string
+		keys    []jose.JSONWebKey
+		wantErr error
+	}{
+		{
+			name: "matching kid",
+			kid:  "kid-1",
+			keys: []jose.JSONWebKey{{Key: pub, KeyID: "kid-1", Algorithm: "RS256", Use: "sig"}},
+		},
+		{
+			name: "absent kid falls back to trying every key",
+			kid:  "",
+			keys: []jose.JSONWebKey{{Key: pub, KeyID: "kid-1", Algorithm: "RS256", Use: "sig"}},
+		},
+		{
+			name: "mismatched kid falls back to trying every key",
+			kid:  "other-kid",
+			keys: []jose.JSONWebKey{{Key: pub, KeyID: "kid-1", Algorithm: "RS256", Use: "sig"}},
+		},
+		{
+			name:    "key present but wrong use is skipped",
+			kid:     "kid-1",
+			keys:    []jose.JSONWebKey{{Key: pub, KeyID: "kid-1", Algorithm: "RS256", Use: "enc"}},
+			wantErr: ErrNoMatchingKey,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			signer, err := jose.NewSigner(
+				jose.SigningKey{Algorithm: jose.RS256, Key: key},
+				(&jose.SignerOptions{}).WithHeader("kid", tc.kid),
+			)
+			if err != nil {
+				t.Fatalf("new signer: %v", err)
+			}
+
+			raw, err := Signed(signer).Claims(testClaims{Subject: "alice"}).CompactSerialize()
+			if err != nil {
+				t.Fatalf("build token: %v", err)
+			}
+
+			tok, err := ParseSigned(raw)
+			if err != nil {
+				t.Fatalf("parse signed: %v", err)
+			}
+
+			var out testClaims
+			err = tok.ClaimsFromKeySet(&jose.JSONWebKeySet{Keys: tc.keys}, &out)
+			if tc.wantErr != nil {
+				if err != tc.wantErr {
+					t.Fatalf("got error %v, want %v", err, tc.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ClaimsFromKeySet: %v", err)
+			}
+			if out.Subject != "alice" {
+				t.Fatalf("got subject %q, want %q", out.Subject, "alice")
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+/*-
+ * Copyright 2016 Zbigniew Mandziejewicz
+ * Copyright 2016 Square, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jwt
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"gopkg.in/square/go-jose.v2"
+)
+
+func mustHMACKey(t *testing.T, n int) []byte {
+	t.Helper()
+	k := make([]byte, n)
+	if _, err := rand.Read(k); err != nil {
+		t.Fatalf("generate hmac key: %v", err)
+	}
+	return k
+}
+
+func TestParseSignedWithOptsRejectsDisallowedAlgorithm(t *testing.T) {
+	hmacKey := mustHMACKey(t, 32)
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: hmacKey}, nil)
+	if err != nil {
+		t.Fatalf("new signer: %v", err)
+	}
+
+	raw, err := Signed(signer).Claims(testClaims{Subject: "alice"}).CompactSerialize()
+	if err != nil {
+		t.Fatalf("build token: %v", err)
+	}
+
+	// An explicit allow-list that excludes HS256 must reject the token
+	// before Verify is ever attempted, regardless of whether hmacKey would
+	// otherwise verify it.
+	_, err = ParseSignedWithOpts(raw, ParseSignedOpts{AllowedSignatureAlgorithms: []jose.SignatureAlgorithm{jose.RS256}})
+	if err != ErrDisallowedAlgorithm {
+		t.Fatalf("got error %v, want %v", err, ErrDisallowedAlgorithm)
+	}
+}
+
+func TestParseSignedWithOptsExplicitListOverridesDefault(t *testing.T) {
+	hmacKey := mustHMACKey(t, 32)
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: hmacKey}, nil)
+	if err != nil {
+		t.Fatalf("new signer: %v", err)
+	}
+
+	raw, err := Signed(signer).Claims(testClaims{Subject: "alice"}).CompactSerialize()
+	if err != nil {
+		t.Fatalf("build token: %v", err)
+	}
+
+	// With HS256 removed from DefaultAllowedSignatureAlgorithms, an explicit,
+	// non-nil list that permits it must be used instead of silently falling
+	// back to the default.
+	saved := DefaultAllowedSignatureAlgorithms
+	DefaultAllowedSignatureAlgorithms = []jose.SignatureAlgorithm{jose.RS256}
+	defer func() { DefaultAllowedSignatureAlgorithms = saved }()
+
+	tok, err := ParseSignedWithOpts(raw, ParseSignedOpts{AllowedSignatureAlgorithms: []jose.SignatureAlgorithm{jose.HS256}})
+	if err != nil {
+		t.Fatalf("ParseSignedWithOpts: %v", err)
+	}
+
+	var out testClaims
+	if err := tok.Claims(hmacKey, &out); err != nil {
+		t.Fatalf("verify claims: %v", err)
+	}
+	if out.Subject != "alice" {
+		t.Fatalf("got subject %q, want %q", out.Subject, "alice")
+	}
+}
+
+func TestParseSignedAndEncryptedWithOptsAppliesAllowListToNestedJWS(t *testing.T) {
+	hmacKey := mustHMACKey(t, 32)
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: hmacKey}, nil)
+	if err != nil {
+		t.Fatalf("new signer: %v", err)
+	}
+
+	encKey := mustRSAKey(t)
+	encrypter, err := jose.NewEncrypter(
+		jose.A128GCM,
+		jose.Recipient{Algorithm: jose.RSA_OAEP, Key: encKey.Public()},
+		(&jose.EncrypterOptions{}).WithContentType("JWT"),
+	)
+	if err != nil {
+		t.Fatalf("new encrypter: %v", err)
+	}
+
+	raw, err := SignedAndEncrypted(signer, encrypter).Claims(testClaims{Subject: "alice"}).CompactSerialize()
+	if err != nil {
+		t.Fatalf("build token: %v", err)
+	}
+
+	nested, err := ParseSignedAndEncryptedWithOpts(raw, ParseSignedAndEncryptedOpts{
+		AllowedSignatureAlgorithms: []jose.SignatureAlgorithm{jose.RS256},
+	})
+	if err != nil {
+		t.Fatalf("ParseSignedAndEncryptedWithOpts: %v", err)
+	}
+
+	var out testClaims
+	err = nested.Decrypt(encKey).Claims(hmacKey, &out)
+	if err != ErrDisallowedAlgorithm {
+		t.Fatalf("got error %v, want %v", err, ErrDisallowedAlgorithm)
+	}
+
+	// The same allow-list, when it does permit the nested JWS's algorithm,
+	// lets Decrypt through to a verifiable token.
+	nested, err = ParseSignedAndEncryptedWithOpts(raw, ParseSignedAndEncryptedOpts{
+		AllowedSignatureAlgorithms: []jose.SignatureAlgorithm{jose.HS256},
+	})
+	if err != nil {
+		t.Fatalf("ParseSignedAndEncryptedWithOpts: %v", err)
+	}
+
+	out = testClaims{}
+	if err := nested.Decrypt(encKey).Claims(hmacKey, &out); err != nil {
+		t.Fatalf("decrypt and verify claims: %v", err)
+	}
+	if out.Subject != "alice" {
+		t.Fatalf("got subject %q, want %q", out.Subject, "alice")
+	}
+}
@@ -0,0 +1,93 @@
+/*-
+ * Copyright 2016 Zbigniew Mandziejewicz
+ * Copyright 2016 Square, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jwt
+
+import "time"
+
+// DefaultLeeway is the clock-skew tolerance applied by Claims.Validate.
+const DefaultLeeway = 1 * time.Minute
+
+// Expected defines the values that registered claims are checked against by
+// Claims.Validate and Claims.ValidateWithLeeway. Zero-valued fields are not
+// checked.
+type Expected struct {
+	// Issuer, if set, must equal the token's "iss" claim.
+	Issuer string
+	// Subject, if set, must equal the token's "sub" claim.
+	Subject string
+	// AnyAudience, if non-empty, must have at least one member in common
+	// with the token's "aud" claim.
+	AnyAudience []string
+	// ID, if set, must equal the token's "jti" claim.
+	ID string
+	// Time is used as "now" when checking "exp"/"nbf". Defaults to
+	// time.Now() when zero.
+	Time time.Time
+}
+
+// Validate checks claims against e using DefaultLeeway for clock skew. See
+// ValidateWithLeeway.
+func (c Claims) Validate(e Expected) error {
+	return c.ValidateWithLeeway(e, DefaultLeeway)
+}
+
+// ValidateWithLeeway checks claims against e, allowing leeway as clock-skew
+// tolerance around "exp" and "nbf". Issuer, Subject and ID are checked for
+// equality; AnyAudience is satisfied if any of its entries appears in the
+// token's "aud" claim. A zero Expected.Time defaults to time.Now().
+func (c Claims) ValidateWithLeeway(e Expected, leeway time.Duration) error {
+	if e.Issuer != "" && e.Issuer != c.Issuer {
+		return ErrInvalidIssuer
+	}
+
+	if e.Subject != "" && e.Subject != c.Subject {
+		return ErrInvalidSubject
+	}
+
+	if e.ID != "" && e.ID != c.ID {
+		return ErrInvalidID
+	}
+
+	if len(e.AnyAudience) != 0 {
+		var ok bool
+		for _, v := range e.AnyAudience {
+			if c.Audience.Contains(v) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return ErrInvalidAudience
+		}
+	}
+
+	now := e.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	if c.NotBefore != nil && now.Add(leeway).Before(c.NotBefore.Time()) {
+		return ErrNotValidYet
+	}
+
+	if c.Expiry != nil && now.Add(-leeway).After(c.Expiry.Time()) {
+		return ErrExpired
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+/*-
+ * Copyright 2016 Zbigniew Mandziejewicz
+ * Copyright 2016 Square, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jwt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateWithLeewayBoundary(t *testing.T) {
+	now := time.Now()
+	claims := Claims{
+		NotBefore: NewNumericDate(now),
+		Expiry:    NewNumericDate(now.Add(time.Minute)),
+	}
+
+	cases := []struct {
+		name    string
+		at      time.Time
+		leeway  time.Duration
+		wantErr error
+	}{
+		{
+			name:   "within validity window",
+			at:     now.Add(30 * time.Second),
+			leeway: time.Minute,
+		},
+		{
+			name:   "before nbf but within leeway",
+			at:     now.Add(-30 * time.Second),
+			leeway: time.Minute,
+		},
+		{
+			name:    "before nbf beyond leeway",
+			at:      now.Add(-2 * time.Minute),
+			leeway:  time.Minute,
+			wantErr: ErrNotValidYet,
+		},
+		{
+			name:   "after exp but within leeway",
+			at:     now.Add(90 * time.Second),
+			leeway: time.Minute,
+		},
+		{
+			name:    "after exp beyond leeway",
+			at:      now.Add(3 * time.Minute),
+			leeway:  time.Minute,
+			wantErr: ErrExpired,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := claims.ValidateWithLeeway(Expected{Time: tc.at}, tc.leeway)
+			if err != tc.wantErr {
+				t.Fatalf("got error %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateChecksIssuerSubjectAudienceAndID(t *testing.T) {
+	claims := Claims{
+		Issuer:   "issuer",
+		Subject:  "subject",
+		Audience: Audience{"aud-1", "aud-2"},
+		ID:       "id",
+	}
+
+	if err := claims.Validate(Expected{Issuer: "issuer", Subject: "subject", AnyAudience: []string{"aud-2"}, ID: "id"}); err != nil {
+		t.Fatalf("expected matching claims to validate, got %v", err)
+	}
+	if err := claims.Validate(Expected{Issuer: "someone-else"}); err != ErrInvalidIssuer {
+		t.Fatalf("got error %v, want %v", err, ErrInvalidIssuer)
+	}
+	if err := claims.Validate(Expected{Subject: "someone-else"}); err != ErrInvalidSubject {
+		t.Fatalf("got error %v, want %v", err, ErrInvalidSubject)
+	}
+	if err := claims.Validate(Expected{ID: "someone-else"}); err != ErrInvalidID {
+		t.Fatalf("got error %v, want %v", err, ErrInvalidID)
+	}
+	if err := claims.Validate(Expected{AnyAudience: []string{"aud-3"}}); err != ErrInvalidAudience {
+		t.Fatalf("got error %v, want %v", err, ErrInvalidAudience)
+	}
+}